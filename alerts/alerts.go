@@ -0,0 +1,141 @@
+// Package alerts evaluates rules against a snapshot of the deployment grid
+// and dispatches notifications on state transitions. Rules operate on the
+// plain Snapshot type below rather than the root package's types, so they
+// can be unit-tested against synthetic data without hitting the network.
+package alerts
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// CellSnapshot is the per-cell state a Rule evaluates.
+type CellSnapshot struct {
+	Environment string
+	Service     string
+	IsError     bool
+	Version     string
+	BuildTime   time.Time
+	ProbedAt    time.Time
+}
+
+// Snapshot is a point-in-time view of the deployment grid to evaluate rules
+// against - real, or synthetic for tests.
+type Snapshot struct {
+	Cells []CellSnapshot
+}
+
+// Alert describes a Rule firing (or resolving) for a specific cell.
+type Alert struct {
+	Rule        string
+	Environment string
+	Service     string
+	Message     string
+	Resolved    bool
+	FiredAt     time.Time
+}
+
+// Notifier dispatches a fired Alert.
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// Rule evaluates a Snapshot, using tracker to look up history such as
+// consecutive failures, and returns the alerts that should currently be
+// firing for it.
+type Rule interface {
+	Name() string
+	Evaluate(snapshot Snapshot, tracker *Tracker) []Alert
+}
+
+// Engine evaluates its Rules on every Process call and notifies on state
+// transitions only, so a Notifier never fires twice for the same ongoing
+// condition.
+type Engine struct {
+	rules     []Rule
+	notifiers []Notifier
+	tracker   *Tracker
+
+	mu     sync.Mutex
+	firing map[string]Alert
+}
+
+// NewEngine builds an Engine evaluating rules and dispatching through
+// notifiers.
+func NewEngine(rules []Rule, notifiers []Notifier) *Engine {
+	return &Engine{
+		rules:     rules,
+		notifiers: notifiers,
+		tracker:   newTracker(),
+		firing:    make(map[string]Alert),
+	}
+}
+
+// Process evaluates every rule against snapshot, notifies on transitions,
+// and returns every alert currently firing.
+func (e *Engine) Process(ctx context.Context, snapshot Snapshot) []Alert {
+	e.tracker.beginRound()
+	for _, cell := range snapshot.Cells {
+		e.tracker.update(cell)
+	}
+
+	var current []Alert
+	for _, rule := range e.rules {
+		current = append(current, rule.Evaluate(snapshot, e.tracker)...)
+	}
+
+	for _, transition := range e.debounce(current) {
+		for _, notifier := range e.notifiers {
+			if err := notifier.Notify(ctx, transition); err != nil {
+				log.Print("Error dispatching alert notification: ", err)
+			}
+		}
+	}
+
+	return current
+}
+
+// Firing returns the alerts currently firing, for display on the HTML page
+// and JSON API.
+func (e *Engine) Firing() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	result := make([]Alert, 0, len(e.firing))
+	for _, alert := range e.firing {
+		result = append(result, alert)
+	}
+	return result
+}
+
+func alertKey(alert Alert) string {
+	return alert.Rule + "|" + alert.Environment + "|" + alert.Service
+}
+
+// debounce updates the set of firing alerts and returns only the ones that
+// newly started or stopped firing this round.
+func (e *Engine) debounce(current []Alert) []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	seen := make(map[string]bool, len(current))
+	var transitions []Alert
+	for _, alert := range current {
+		key := alertKey(alert)
+		seen[key] = true
+		if _, alreadyFiring := e.firing[key]; !alreadyFiring {
+			e.firing[key] = alert
+			transitions = append(transitions, alert)
+		}
+	}
+	for key, alert := range e.firing {
+		if seen[key] {
+			continue
+		}
+		delete(e.firing, key)
+		alert.Resolved = true
+		transitions = append(transitions, alert)
+	}
+	return transitions
+}
@@ -0,0 +1,68 @@
+package alerts
+
+import (
+	"context"
+	"testing"
+)
+
+type recordingNotifier struct {
+	notified []Alert
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, alert Alert) error {
+	n.notified = append(n.notified, alert)
+	return nil
+}
+
+func TestEngineProcessDebouncesNotifications(t *testing.T) {
+	notifier := &recordingNotifier{}
+	engine := NewEngine([]Rule{ConsecutiveErrorsRule{Threshold: 1}}, []Notifier{notifier})
+	errorCell := Snapshot{Cells: []CellSnapshot{{Environment: "prod", Service: "orders", IsError: true}}}
+	healthyCell := Snapshot{Cells: []CellSnapshot{{Environment: "prod", Service: "orders", Version: "1.0.0"}}}
+
+	firing := engine.Process(context.Background(), errorCell)
+	if len(firing) != 1 {
+		t.Fatalf("round 1 firing = %v, want 1 alert", firing)
+	}
+	if len(notifier.notified) != 1 {
+		t.Fatalf("round 1 notifications = %d, want 1", len(notifier.notified))
+	}
+
+	// Same condition still firing: no new notification, but still reported
+	// as currently firing.
+	firing = engine.Process(context.Background(), errorCell)
+	if len(firing) != 1 {
+		t.Fatalf("round 2 firing = %v, want 1 alert", firing)
+	}
+	if len(notifier.notified) != 1 {
+		t.Fatalf("round 2 notifications = %d, want still 1 (no repeat)", len(notifier.notified))
+	}
+
+	// Cell recovers: one resolved notification, nothing currently firing.
+	firing = engine.Process(context.Background(), healthyCell)
+	if len(firing) != 0 {
+		t.Fatalf("round 3 firing = %v, want 0 alerts", firing)
+	}
+	if len(notifier.notified) != 2 || !notifier.notified[1].Resolved {
+		t.Fatalf("round 3 notifications = %v, want a second, resolved notification", notifier.notified)
+	}
+}
+
+func TestEngineFiringReflectsCurrentState(t *testing.T) {
+	engine := NewEngine([]Rule{ConsecutiveErrorsRule{Threshold: 1}}, nil)
+	if got := engine.Firing(); len(got) != 0 {
+		t.Fatalf("Firing before any Process = %v, want none", got)
+	}
+
+	snapshot := Snapshot{Cells: []CellSnapshot{{Environment: "prod", Service: "orders", IsError: true}}}
+	engine.Process(context.Background(), snapshot)
+	firing := engine.Firing()
+	if len(firing) != 1 || firing[0].Environment != "prod" || firing[0].Service != "orders" {
+		t.Fatalf("Firing after a failing round = %v, want one alert for prod/orders", firing)
+	}
+
+	engine.Process(context.Background(), Snapshot{Cells: []CellSnapshot{{Environment: "prod", Service: "orders", Version: "1.0.0"}}})
+	if got := engine.Firing(); len(got) != 0 {
+		t.Fatalf("Firing after recovery = %v, want none", got)
+	}
+}
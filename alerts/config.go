@@ -0,0 +1,118 @@
+package alerts
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Config is the JSON-declarable shape of an alerting setup, meant to be
+// embedded in the root package's Configuration.
+type Config struct {
+	Rules     []RuleConfig
+	Notifiers []NotifierConfig
+}
+
+// RuleConfig declares one Rule. Type selects which fields apply:
+// "consecutive-errors" uses Threshold, "version-divergence" uses
+// MaxRefreshesBehind, "stale-build" uses MaxAge.
+type RuleConfig struct {
+	Type               string
+	Threshold          int
+	MaxRefreshesBehind int
+	MaxAge             time.Duration
+}
+
+// NotifierConfig declares one Notifier. Type selects which fields apply:
+// "webhook" and "slack" and "discord" use URL, "smtp" uses SMTP, "exec"
+// uses Exec.
+type NotifierConfig struct {
+	Type string
+	URL  string
+	SMTP *SMTPNotifierConfig
+	Exec *ExecNotifierConfig
+}
+
+type SMTPNotifierConfig struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+type ExecNotifierConfig struct {
+	Command string
+	Args    []string
+}
+
+// BuildEngine turns a Config into a ready-to-use Engine.
+func BuildEngine(config Config) (*Engine, error) {
+	rules := make([]Rule, 0, len(config.Rules))
+	for _, ruleConfig := range config.Rules {
+		rule, err := buildRule(ruleConfig)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	notifiers := make([]Notifier, 0, len(config.Notifiers))
+	for _, notifierConfig := range config.Notifiers {
+		notifier, err := buildNotifier(notifierConfig)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, notifier)
+	}
+
+	return NewEngine(rules, notifiers), nil
+}
+
+func buildRule(config RuleConfig) (Rule, error) {
+	switch config.Type {
+	case "consecutive-errors":
+		if config.Threshold <= 0 {
+			return nil, fmt.Errorf("consecutive-errors rule requires a positive Threshold, got %d", config.Threshold)
+		}
+		return ConsecutiveErrorsRule{Threshold: config.Threshold}, nil
+	case "version-divergence":
+		return VersionDivergenceRule{MaxRefreshesBehind: config.MaxRefreshesBehind}, nil
+	case "stale-build":
+		return StaleBuildRule{MaxAge: config.MaxAge}, nil
+	default:
+		return nil, fmt.Errorf("unknown alert rule type %q", config.Type)
+	}
+}
+
+func buildNotifier(config NotifierConfig) (Notifier, error) {
+	switch config.Type {
+	case "webhook":
+		return WebhookNotifier{URL: config.URL}, nil
+	case "slack":
+		return SlackNotifier{URL: config.URL}, nil
+	case "discord":
+		return DiscordNotifier{URL: config.URL}, nil
+	case "smtp":
+		if config.SMTP == nil {
+			return nil, fmt.Errorf("smtp notifier requires an SMTP block")
+		}
+		var auth smtp.Auth
+		if config.SMTP.Username != "" {
+			host := config.SMTP.Addr
+			if idx := strings.IndexByte(host, ':'); idx >= 0 {
+				host = host[:idx]
+			}
+			auth = smtp.PlainAuth("", config.SMTP.Username, config.SMTP.Password, host)
+		}
+		return SMTPNotifier{Addr: config.SMTP.Addr, Auth: auth, From: config.SMTP.From, To: config.SMTP.To}, nil
+	case "exec":
+		if config.Exec == nil {
+			return nil, fmt.Errorf("exec notifier requires an Exec block")
+		}
+		return ExecNotifier{Command: config.Exec.Command, Args: config.Exec.Args}, nil
+	default:
+		return nil, fmt.Errorf("unknown alert notifier type %q", config.Type)
+	}
+}
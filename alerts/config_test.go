@@ -0,0 +1,21 @@
+package alerts
+
+import "testing"
+
+func TestBuildRuleRejectsNonPositiveThreshold(t *testing.T) {
+	for _, threshold := range []int{0, -1} {
+		if _, err := buildRule(RuleConfig{Type: "consecutive-errors", Threshold: threshold}); err == nil {
+			t.Fatalf("buildRule with Threshold=%d, want an error", threshold)
+		}
+	}
+}
+
+func TestBuildRuleAcceptsPositiveThreshold(t *testing.T) {
+	rule, err := buildRule(RuleConfig{Type: "consecutive-errors", Threshold: 3})
+	if err != nil {
+		t.Fatalf("buildRule: %v", err)
+	}
+	if rule.Name() != "consecutive-errors" {
+		t.Fatalf("rule.Name() = %q, want %q", rule.Name(), "consecutive-errors")
+	}
+}
@@ -0,0 +1,123 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"os/exec"
+)
+
+// WebhookNotifier POSTs the Alert as JSON to a generic webhook URL.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (w WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, w.client(), w.URL, payload)
+}
+
+func (w WebhookNotifier) client() *http.Client {
+	if w.Client != nil {
+		return w.Client
+	}
+	return http.DefaultClient
+}
+
+// SlackNotifier posts an Alert to a Slack incoming webhook.
+type SlackNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s SlackNotifier) Notify(ctx context.Context, alert Alert) error {
+	payload, err := json.Marshal(struct {
+		Text string `json:"text"`
+	}{Text: formatAlert(alert)})
+	if err != nil {
+		return err
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return postJSON(ctx, client, s.URL, payload)
+}
+
+// DiscordNotifier posts an Alert to a Discord incoming webhook.
+type DiscordNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (d DiscordNotifier) Notify(ctx context.Context, alert Alert) error {
+	payload, err := json.Marshal(struct {
+		Content string `json:"content"`
+	}{Content: formatAlert(alert)})
+	if err != nil {
+		return err
+	}
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return postJSON(ctx, client, d.URL, payload)
+}
+
+func formatAlert(alert Alert) string {
+	if alert.Resolved {
+		return fmt.Sprintf("[resolved] %s: %s", alert.Rule, alert.Message)
+	}
+	return fmt.Sprintf("[firing] %s: %s", alert.Rule, alert.Message)
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s responded with %d", url, res.StatusCode)
+	}
+	return nil
+}
+
+// SMTPNotifier emails an Alert through an SMTP relay.
+type SMTPNotifier struct {
+	Addr string
+	Auth smtp.Auth
+	From string
+	To   []string
+}
+
+func (s SMTPNotifier) Notify(ctx context.Context, alert Alert) error {
+	body := fmt.Sprintf("Subject: %s\r\n\r\n%s\r\n", alert.Rule, formatAlert(alert))
+	return smtp.SendMail(s.Addr, s.Auth, s.From, s.To, []byte(body))
+}
+
+// ExecNotifier runs a local command for each alert, passing the rule,
+// environment, service and the formatted alert text (see formatAlert) as
+// arguments after Args.
+type ExecNotifier struct {
+	Command string
+	Args    []string
+}
+
+func (e ExecNotifier) Notify(ctx context.Context, alert Alert) error {
+	args := append(append([]string{}, e.Args...), alert.Rule, alert.Environment, alert.Service, formatAlert(alert))
+	cmd := exec.CommandContext(ctx, e.Command, args...)
+	return cmd.Run()
+}
@@ -0,0 +1,112 @@
+package alerts
+
+import (
+	"fmt"
+	"time"
+)
+
+// ConsecutiveErrorsRule fires for a cell once it has failed Threshold
+// probes in a row.
+type ConsecutiveErrorsRule struct {
+	Threshold int
+}
+
+func (r ConsecutiveErrorsRule) Name() string { return "consecutive-errors" }
+
+func (r ConsecutiveErrorsRule) Evaluate(snapshot Snapshot, tracker *Tracker) []Alert {
+	var fired []Alert
+	for _, cell := range snapshot.Cells {
+		count := tracker.ConsecutiveErrors(cell.Environment, cell.Service)
+		if count >= r.Threshold {
+			fired = append(fired, Alert{
+				Rule:        r.Name(),
+				Environment: cell.Environment,
+				Service:     cell.Service,
+				Message:     fmt.Sprintf("%s/%s has failed %d consecutive probes", cell.Environment, cell.Service, count),
+				FiredAt:     cell.ProbedAt,
+			})
+		}
+	}
+	return fired
+}
+
+// VersionDivergenceRule fires for a cell still running an older version of
+// a service once a newer version has been observed elsewhere for more than
+// MaxRefreshesBehind rounds - e.g. prod lagging staging during a rollout.
+type VersionDivergenceRule struct {
+	MaxRefreshesBehind int
+}
+
+func (r VersionDivergenceRule) Name() string { return "version-divergence" }
+
+func (r VersionDivergenceRule) Evaluate(snapshot Snapshot, tracker *Tracker) []Alert {
+	latestRoundByService := make(map[string]int)
+	for _, cell := range snapshot.Cells {
+		if cell.IsError || cell.Version == "" {
+			continue
+		}
+		round, ok := tracker.FirstSeenRound(cell.Service, cell.Version)
+		if !ok {
+			continue
+		}
+		if round > latestRoundByService[cell.Service] {
+			latestRoundByService[cell.Service] = round
+		}
+	}
+
+	currentRound := tracker.CurrentRound()
+	var fired []Alert
+	for _, cell := range snapshot.Cells {
+		if cell.IsError || cell.Version == "" {
+			continue
+		}
+		latestRound, ok := latestRoundByService[cell.Service]
+		if !ok {
+			continue
+		}
+		ownRound, _ := tracker.FirstSeenRound(cell.Service, cell.Version)
+		if ownRound >= latestRound {
+			continue
+		}
+		refreshesBehind := currentRound - latestRound
+		if refreshesBehind > r.MaxRefreshesBehind {
+			fired = append(fired, Alert{
+				Rule:        r.Name(),
+				Environment: cell.Environment,
+				Service:     cell.Service,
+				Message: fmt.Sprintf("%s/%s is on %q while a newer version has been live elsewhere for %d refreshes",
+					cell.Environment, cell.Service, cell.Version, refreshesBehind),
+				FiredAt: cell.ProbedAt,
+			})
+		}
+	}
+	return fired
+}
+
+// StaleBuildRule fires for a cell whose reported BuildTime is older than
+// MaxAge.
+type StaleBuildRule struct {
+	MaxAge time.Duration
+}
+
+func (r StaleBuildRule) Name() string { return "stale-build" }
+
+func (r StaleBuildRule) Evaluate(snapshot Snapshot, tracker *Tracker) []Alert {
+	var fired []Alert
+	for _, cell := range snapshot.Cells {
+		if cell.IsError || cell.BuildTime.IsZero() {
+			continue
+		}
+		age := cell.ProbedAt.Sub(cell.BuildTime)
+		if age > r.MaxAge {
+			fired = append(fired, Alert{
+				Rule:        r.Name(),
+				Environment: cell.Environment,
+				Service:     cell.Service,
+				Message:     fmt.Sprintf("%s/%s build is %s old, older than the configured max of %s", cell.Environment, cell.Service, age, r.MaxAge),
+				FiredAt:     cell.ProbedAt,
+			})
+		}
+	}
+	return fired
+}
@@ -0,0 +1,72 @@
+package alerts
+
+import "testing"
+
+func TestConsecutiveErrorsRule(t *testing.T) {
+	tracker := newTracker()
+	rule := ConsecutiveErrorsRule{Threshold: 2}
+	cell := CellSnapshot{Environment: "prod", Service: "orders", IsError: true}
+
+	tracker.beginRound()
+	tracker.update(cell)
+	if got := rule.Evaluate(Snapshot{Cells: []CellSnapshot{cell}}, tracker); len(got) != 0 {
+		t.Fatalf("fired after 1 error, want 0 alerts, got %v", got)
+	}
+
+	tracker.beginRound()
+	tracker.update(cell)
+	got := rule.Evaluate(Snapshot{Cells: []CellSnapshot{cell}}, tracker)
+	if len(got) != 1 {
+		t.Fatalf("fired after 2 errors, want 1 alert, got %v", got)
+	}
+	if got[0].Environment != "prod" || got[0].Service != "orders" {
+		t.Fatalf("unexpected alert cell: %+v", got[0])
+	}
+}
+
+func TestVersionDivergenceRule(t *testing.T) {
+	tracker := newTracker()
+	rule := VersionDivergenceRule{MaxRefreshesBehind: 1}
+
+	rounds := []Snapshot{
+		{Cells: []CellSnapshot{
+			{Environment: "staging", Service: "orders", Version: "1.0.0"},
+			{Environment: "prod", Service: "orders", Version: "1.0.0"},
+		}},
+		{Cells: []CellSnapshot{
+			{Environment: "staging", Service: "orders", Version: "1.1.0"},
+			{Environment: "prod", Service: "orders", Version: "1.0.0"},
+		}},
+		{Cells: []CellSnapshot{
+			{Environment: "staging", Service: "orders", Version: "1.1.0"},
+			{Environment: "prod", Service: "orders", Version: "1.0.0"},
+		}},
+		{Cells: []CellSnapshot{
+			{Environment: "staging", Service: "orders", Version: "1.1.0"},
+			{Environment: "prod", Service: "orders", Version: "1.0.0"},
+		}},
+	}
+
+	var lastFired []Alert
+	for _, snapshot := range rounds {
+		tracker.beginRound()
+		for _, cell := range snapshot.Cells {
+			tracker.update(cell)
+		}
+		lastFired = rule.Evaluate(snapshot, tracker)
+	}
+
+	if len(lastFired) != 1 || lastFired[0].Environment != "prod" {
+		t.Fatalf("want prod alone flagged as lagging, got %v", lastFired)
+	}
+}
+
+func TestStaleBuildRule(t *testing.T) {
+	tracker := newTracker()
+	rule := StaleBuildRule{MaxAge: 0}
+
+	fresh := CellSnapshot{Environment: "prod", Service: "orders"}
+	if got := rule.Evaluate(Snapshot{Cells: []CellSnapshot{fresh}}, tracker); len(got) != 0 {
+		t.Fatalf("cell with a zero BuildTime should be ignored, got %v", got)
+	}
+}
@@ -0,0 +1,98 @@
+package alerts
+
+import "sync"
+
+// Tracker keeps the per-cell history an Engine needs across refreshes - how
+// many consecutive probes have failed, and when each version was first
+// observed - so Rules can stay stateless themselves.
+type Tracker struct {
+	mu sync.Mutex
+
+	refreshCounter int
+	cells          map[string]*cellHistory
+	// firstSeen maps "service|version" to the refreshCounter value at which
+	// that version was first observed in any environment.
+	firstSeen map[string]int
+}
+
+type cellHistory struct {
+	consecutiveErrors int
+	currentVersion    string
+}
+
+func newTracker() *Tracker {
+	return &Tracker{
+		cells:     make(map[string]*cellHistory),
+		firstSeen: make(map[string]int),
+	}
+}
+
+func cellKey(environment, service string) string {
+	return environment + "|" + service
+}
+
+func serviceVersionKey(service, version string) string {
+	return service + "|" + version
+}
+
+// update folds one cell's latest observation into the tracker. It is called
+// once per cell per Process round, before any Rule is evaluated.
+func (t *Tracker) update(cell CellSnapshot) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := cellKey(cell.Environment, cell.Service)
+	history, ok := t.cells[key]
+	if !ok {
+		history = &cellHistory{}
+		t.cells[key] = history
+	}
+
+	if cell.IsError {
+		history.consecutiveErrors++
+	} else {
+		history.consecutiveErrors = 0
+		history.currentVersion = cell.Version
+		versionKey := serviceVersionKey(cell.Service, cell.Version)
+		if _, seen := t.firstSeen[versionKey]; !seen {
+			t.firstSeen[versionKey] = t.refreshCounter
+		}
+	}
+}
+
+// beginRound advances the tracker's refresh counter. The Engine calls this
+// once per Process before updating any cell.
+func (t *Tracker) beginRound() {
+	t.mu.Lock()
+	t.refreshCounter++
+	t.mu.Unlock()
+}
+
+// ConsecutiveErrors returns how many probes in a row have failed for
+// environment/service.
+func (t *Tracker) ConsecutiveErrors(environment, service string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	history, ok := t.cells[cellKey(environment, service)]
+	if !ok {
+		return 0
+	}
+	return history.consecutiveErrors
+}
+
+// FirstSeenRound returns the refresh round in which version was first
+// observed for service in any environment, and whether it has been seen at
+// all.
+func (t *Tracker) FirstSeenRound(service, version string) (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	round, ok := t.firstSeen[serviceVersionKey(service, version)]
+	return round, ok
+}
+
+// CurrentRound returns the refresh round the tracker is currently on.
+func (t *Tracker) CurrentRound() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.refreshCounter
+}
@@ -0,0 +1,52 @@
+package alerts
+
+import "testing"
+
+func TestTrackerConsecutiveErrors(t *testing.T) {
+	tracker := newTracker()
+
+	cell := CellSnapshot{Environment: "prod", Service: "orders"}
+	for i := 0; i < 3; i++ {
+		tracker.beginRound()
+		errored := cell
+		errored.IsError = true
+		tracker.update(errored)
+	}
+	if got := tracker.ConsecutiveErrors("prod", "orders"); got != 3 {
+		t.Fatalf("ConsecutiveErrors = %d, want 3", got)
+	}
+
+	tracker.beginRound()
+	ok := cell
+	ok.Version = "1.0.0"
+	tracker.update(ok)
+	if got := tracker.ConsecutiveErrors("prod", "orders"); got != 0 {
+		t.Fatalf("ConsecutiveErrors after a success = %d, want 0", got)
+	}
+}
+
+func TestTrackerFirstSeenRound(t *testing.T) {
+	tracker := newTracker()
+
+	tracker.beginRound() // round 1
+	tracker.update(CellSnapshot{Environment: "staging", Service: "orders", Version: "1.0.0"})
+
+	tracker.beginRound() // round 2
+	tracker.update(CellSnapshot{Environment: "prod", Service: "orders", Version: "1.0.0"})
+	tracker.update(CellSnapshot{Environment: "staging", Service: "orders", Version: "1.1.0"})
+
+	round, ok := tracker.FirstSeenRound("orders", "1.0.0")
+	if !ok || round != 1 {
+		t.Fatalf("FirstSeenRound(1.0.0) = (%d, %v), want (1, true)", round, ok)
+	}
+	round, ok = tracker.FirstSeenRound("orders", "1.1.0")
+	if !ok || round != 2 {
+		t.Fatalf("FirstSeenRound(1.1.0) = (%d, %v), want (2, true)", round, ok)
+	}
+	if _, ok := tracker.FirstSeenRound("orders", "9.9.9"); ok {
+		t.Fatal("FirstSeenRound(9.9.9) ok = true, want false for an unseen version")
+	}
+	if got := tracker.CurrentRound(); got != 2 {
+		t.Fatalf("CurrentRound = %d, want 2", got)
+	}
+}
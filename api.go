@@ -0,0 +1,243 @@
+package web_service_overview
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DanielHons/web-service-overview/history"
+)
+
+// CellUpdate is published whenever fetchVersions refreshes a DeploymentCell.
+type CellUpdate struct {
+	Environment string                `json:"environment"`
+	Service     string                `json:"service"`
+	Content     DeploymentCellContent `json:"content"`
+}
+
+// EventHub fans a stream of CellUpdates out to any number of subscribers. It
+// backs the /api/events SSE endpoint served by NewMux.
+type EventHub struct {
+	mu          sync.Mutex
+	subscribers map[chan CellUpdate]struct{}
+}
+
+// NewEventHub creates an empty hub ready to accept subscribers.
+func NewEventHub() *EventHub {
+	return &EventHub{subscribers: make(map[chan CellUpdate]struct{})}
+}
+
+// Subscribe registers a new listener and returns the channel it will
+// receive CellUpdates on. Callers must Unsubscribe when done.
+func (h *EventHub) Subscribe() chan CellUpdate {
+	ch := make(chan CellUpdate, 16)
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch
+}
+
+// Unsubscribe removes and closes a channel returned by Subscribe.
+func (h *EventHub) Unsubscribe(ch chan CellUpdate) {
+	h.mu.Lock()
+	delete(h.subscribers, ch)
+	close(ch)
+	h.mu.Unlock()
+}
+
+// Publish fans update out to every current subscriber. A subscriber whose
+// buffer is full misses this update rather than blocking the probe loop; it
+// stays subscribed and receives later updates.
+func (h *EventHub) Publish(update CellUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers {
+		select {
+		case ch <- update:
+		default:
+		}
+	}
+}
+
+// apiOverview is the JSON shape of /api/overview: the grid's layout plus
+// each cell's Content, deliberately omitting DeployedService so a
+// ServiceInstance's Transport credentials and Environment.Auth/TLS never
+// reach the response.
+type apiOverview struct {
+	Environments []Environment          `json:"environments"`
+	WebServices  []WebServiceDefinition `json:"webServices"`
+	Rows         []apiRow               `json:"rows"`
+}
+
+type apiRow struct {
+	Name  string       `json:"name"`
+	Cells []CellUpdate `json:"cells"`
+}
+
+// toAPIOverview converts a live DeploymentOverview into the DTO served over
+// JSON, dropping every field that isn't safe to expose to an API client.
+func toAPIOverview(overview *DeploymentOverview) apiOverview {
+	rows := make([]apiRow, len(overview.Rows))
+	for i, row := range overview.Rows {
+		cells := make([]CellUpdate, len(row.Cells))
+		for j, cell := range row.Cells {
+			cells[j] = CellUpdate{
+				Environment: cell.DeployedService.Environment.Name,
+				Service:     cell.DeployedService.Definition.Name,
+				Content:     cell.Content,
+			}
+		}
+		rows[i] = apiRow{Name: row.Name, Cells: cells}
+	}
+	return apiOverview{Environments: overview.Environments, WebServices: overview.WebServices, Rows: rows}
+}
+
+// NewMux serves the deployment overview as JSON and SSE, mountable next to
+// the HTML template served by WriteTable:
+//
+//	GET /api/overview                 - the grid as JSON (apiOverview)
+//	GET /api/service/{env}/{name}     - a single cell's Content as JSON (CellUpdate)
+//	GET /api/events                   - an SSE stream of CellUpdates
+//	GET /api/history/{env}/{name}     - that cell's recorded history.Events, optionally ?since=<RFC3339>
+//	GET /api/alerts                   - every alert currently firing
+func NewMux(d *Deployment) http.Handler {
+	hub := NewEventHub()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/overview", func(w http.ResponseWriter, r *http.Request) {
+		overview := d.fetchAndPublish(r.Context(), hub)
+		writeJSON(w, toAPIOverview(overview))
+	})
+	mux.HandleFunc("/api/alerts", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, d.Alerts.Firing())
+	})
+	mux.HandleFunc("/api/service/", func(w http.ResponseWriter, r *http.Request) {
+		environment, service, ok := parseServicePath(r.URL.Path, "/api/service/")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		overview := d.fetchAndPublish(r.Context(), hub)
+		cell := findCell(overview, environment, service)
+		if cell == nil {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, CellUpdate{Environment: environment, Service: service, Content: cell.Content})
+	})
+	mux.HandleFunc("/api/events", func(w http.ResponseWriter, r *http.Request) {
+		serveEvents(w, r, hub)
+	})
+	mux.HandleFunc("/api/history/", func(w http.ResponseWriter, r *http.Request) {
+		environment, service, ok := parseServicePath(r.URL.Path, "/api/history/")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if d.History == nil {
+			http.Error(w, "history recording is not configured", http.StatusNotImplemented)
+			return
+		}
+		filter := history.Filter{Environment: environment, Service: service}
+		if since := r.URL.Query().Get("since"); since != "" {
+			parsed, err := time.Parse(time.RFC3339, since)
+			if err != nil {
+				http.Error(w, "invalid since: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			filter.Since = parsed
+		}
+		events, err := d.History.Query(r.Context(), filter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, events)
+	})
+	return mux
+}
+
+// fetchAndPublish refreshes every cell and republishes each update to hub's
+// subscribers as it lands, returning the resulting overview.
+func (d *Deployment) fetchAndPublish(ctx context.Context, hub *EventHub) *DeploymentOverview {
+	updates := make(chan CellUpdate)
+	done := make(chan struct{})
+	go func() {
+		for update := range updates {
+			hub.Publish(update)
+		}
+		close(done)
+	}()
+	d.fetchVersions(ctx, updates)
+	close(updates)
+	<-done
+	d.evaluateAlerts(ctx)
+	return d.makeOverview()
+}
+
+// parseServicePath extracts the {env}/{name} path parameters after prefix,
+// shared by the /api/service/ and /api/history/ routes.
+func parseServicePath(path, prefix string) (environment, service string, ok bool) {
+	trimmed := strings.TrimPrefix(path, prefix)
+	parts := strings.SplitN(trimmed, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+func findCell(overview *DeploymentOverview, environment, service string) *DeploymentCell {
+	for _, row := range overview.Rows {
+		for _, cell := range row.Cells {
+			if cell.DeployedService.Environment.Name == environment && cell.DeployedService.Definition.Name == service {
+				return cell
+			}
+		}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Print("Error writing JSON response", err)
+	}
+}
+
+// serveEvents streams CellUpdates to the client as Server-Sent Events until
+// it disconnects.
+func serveEvents(w http.ResponseWriter, r *http.Request, hub *EventHub) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch := hub.Subscribe()
+	defer hub.Unsubscribe(ch)
+
+	for {
+		select {
+		case update, chOk := <-ch:
+			if !chOk {
+				return
+			}
+			payload, err := json.Marshal(update)
+			if err != nil {
+				log.Print("Error marshalling SSE event", err)
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", payload)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Command overview wraps the web_service_overview library for use in
+// scripts and CI pipelines.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	wso "github.com/DanielHons/web-service-overview"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: overview <command> [flags]")
+		fmt.Fprintln(os.Stderr, "commands:")
+		fmt.Fprintln(os.Stderr, "  wait-for-version   block until every targeted cell reaches a version")
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "wait-for-version":
+		runWaitForVersion(os.Args[2:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", os.Args[1])
+		os.Exit(2)
+	}
+}
+
+func runWaitForVersion(args []string) {
+	fs := flag.NewFlagSet("wait-for-version", flag.ExitOnError)
+	configFile := fs.String("config", "config.json", "path to the deployment configuration")
+	service := fs.String("service", "", "name of the WebServiceDefinition to wait on")
+	target := fs.String("target", "", `version to wait for, or "any-newer" to wait for any change`)
+	environments := fs.String("environments", "", "comma-separated environment names, empty means all")
+	pollInterval := fs.Duration("poll-interval", 5*time.Second, "how often to re-probe")
+	timeout := fs.Duration("timeout", 10*time.Minute, "overall timeout")
+	minStableProbes := fs.Int("min-stable-probes", 1, "consecutive probes required before the target counts as reached")
+	fs.Parse(args)
+
+	if *service == "" {
+		fmt.Fprintln(os.Stderr, "-service is required")
+		os.Exit(2)
+	}
+	if *target == "" {
+		fmt.Fprintln(os.Stderr, "-target is required")
+		os.Exit(2)
+	}
+
+	configuration := wso.FileConfiguration(*configFile)
+	deployment := wso.NewDeployment(configuration, wso.SimpleUrlConstructor{})
+
+	opts := wso.WaitOptions{
+		PollInterval:    *pollInterval,
+		Timeout:         *timeout,
+		MinStableProbes: *minStableProbes,
+	}
+	if *environments != "" {
+		opts.Environments = strings.Split(*environments, ",")
+	}
+
+	waitTarget := *target
+	if waitTarget == "any-newer" {
+		waitTarget = wso.AnyNewer
+	}
+
+	if err := deployment.WaitForVersion(context.Background(), *service, waitTarget, opts); err != nil {
+		fmt.Fprintln(os.Stderr, "wait-for-version failed:", err)
+		os.Exit(1)
+	}
+	fmt.Println("converged")
+}
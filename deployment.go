@@ -1,6 +1,7 @@
 package web_service_overview
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"html/template"
@@ -9,9 +10,12 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"strings"
 	"sync"
 	"time"
+
+	"github.com/DanielHons/web-service-overview/alerts"
+	"github.com/DanielHons/web-service-overview/history"
+	"github.com/DanielHons/web-service-overview/metrics"
 )
 
 var versionOverviewTemplate = template.Must(template.New("versionOverview").Parse(`<!DOCTYPE html>
@@ -41,13 +45,22 @@ var versionOverviewTemplate = template.Must(template.New("versionOverview").Pars
         <td></td>
         {{range .Environments}}
             <td><a href="{{.BaseUrl}}">{{.Name}}</a></td> {{else}} (No environments found) {{end}}
+        <td>Alerts</td>
     </tr>
     {{range $row := $rows}}
         <tr {{ if ($row.Even) }} class="A" {{else}} class="B" {{end}}>
             <td>{{$row.Name}}</td>
             {{range $cell := $row.Cells}}
-                <td {{ if $cell.Content.IsError}} class="error" {{end}} title="{{$cell.Content.Title}}">{{ $cell.Content.Text}}</td>
+                <td {{ if $cell.Content.IsError}} class="error" {{end}} title="{{$cell.Content.Title}}">
+                    {{ $cell.Content.Text}}
+                    {{ if $cell.Content.RecentChanges }}
+                        <ul class="changes">
+                            {{range $cell.Content.RecentChanges}}<li>{{.}}</li>{{end}}
+                        </ul>
+                    {{end}}
+                </td>
             {{end}}
+            <td>{{range $row.Alerts}}{{.}}<br/>{{else}}-{{end}}</td>
         </tr> {{end}}
 </table>
 </body>
@@ -72,6 +85,7 @@ type UrlAssembler interface {
 
 type ServiceInstance struct {
 	UrlAssembler    UrlAssembler
+	Transport       ProbeTransport
 	Definition      WebServiceDefinition
 	Environment     Environment
 	Status          *ServiceStatus
@@ -80,18 +94,57 @@ type ServiceInstance struct {
 }
 
 type Deployment struct {
-	config Configuration
-	Rows   []Row
+	config  Configuration
+	Rows    []Row
+	Metrics *metrics.Collectors
+	Alerts  *alerts.Engine
+	// History records version history after every successful probe, and
+	// backs the "recent changes" panel. Nil disables recording.
+	History history.Store
+	// RecentChangesLimit bounds how many transitions the "recent changes"
+	// panel shows per cell. Zero means historyChangesLimitDefault.
+	RecentChangesLimit int
+	// MaxConcurrency bounds how many cells fetchVersions probes at once.
+	// Zero means unbounded.
+	MaxConcurrency int
+	// mu guards Rows and every cell's Content against concurrent refreshes
+	// (e.g. two overlapping HTTP requests, or a request racing
+	// WaitForVersion). It's a pointer so it's shared across the value
+	// copies of Deployment its methods take. Always non-nil on a Deployment
+	// built via NewDeployment.
+	mu *sync.RWMutex
 }
 
+// historyChangesLimitDefault is how many version transitions are shown per
+// cell when Deployment.RecentChangesLimit is unset.
+const historyChangesLimitDefault = 5
+
+// historyQueryLimitFactor scales RecentChangesLimit into how many raw
+// events recordHistory asks the store for, since Transitions then dedupes
+// consecutive repeats out of that window.
+const historyQueryLimitFactor = 4
+
 type Row struct {
 	Even  bool
 	Name  string
 	Cells []*DeploymentCell
 }
 
+// Alerts returns every alert currently firing for any cell in this row,
+// used by the HTML template's Alerts column.
+func (r Row) Alerts() []string {
+	var firing []string
+	for _, cell := range r.Cells {
+		firing = append(firing, cell.Content.Alerts...)
+	}
+	return firing
+}
+
 type DeploymentCell struct {
-	DeployedService *ServiceInstance
+	// DeployedService is json:"-" since ServiceInstance carries the probe's
+	// Transport and Environment, which can hold credentials; API responses
+	// serialize Content instead (see api.go's toAPIOverview).
+	DeployedService *ServiceInstance `json:"-"`
 	Content         DeploymentCellContent
 }
 
@@ -99,21 +152,65 @@ type DeploymentCellContent struct {
 	Text    string
 	Title   string
 	IsError bool
+	// ErrorKind is the classification from errorKind, empty on success.
+	ErrorKind string
+	// LastProbedAt is when this content was last refreshed by fetchVersions.
+	LastProbedAt time.Time
+	// Alerts holds the messages of any alerts currently firing for this
+	// cell, populated by Deployment.Alerts after each refresh.
+	Alerts []string
+	// RecentChanges holds the last few version transitions for this cell,
+	// formatted for display, populated from Deployment.History.
+	RecentChanges []string
 }
 
 type Configuration struct {
 	Environments []Environment
 	WebServices  []WebServiceDefinition
+	Alerts       alerts.Config
+	History      history.Config
 }
 
 type Environment struct {
 	Name    string
 	BaseUrl string
+	// Auth and TLS are optional; a nil Auth/TLS probes the BaseUrl with a
+	// bare, unauthenticated client, preserving the historic behaviour.
+	// json:"-" since both can carry credentials and Environment is exposed
+	// on the /api/overview response. The tag applies to decoding as well, so
+	// FileConfiguration never reads Auth or TLS from a JSON config file; they
+	// take effect only when set on the Configuration in Go code.
+	Auth *AuthConfig `json:"-"`
+	TLS  *TLSConfig  `json:"-"`
+	// DefaultTimeout is the probe timeout used for services deployed to
+	// this environment that don't set their own Timeout. Zero means
+	// defaultProbeTimeout.
+	DefaultTimeout time.Duration
 }
 
 type WebServiceDefinition struct {
 	Name         string
 	PathSelector string
+	// Timeout overrides the probe timeout for this service, taking
+	// precedence over the environment's DefaultTimeout. Zero means inherit.
+	Timeout time.Duration
+}
+
+// defaultProbeTimeout is used when neither a WebServiceDefinition nor its
+// Environment declare a Timeout.
+const defaultProbeTimeout = time.Second
+
+// probeTimeout resolves the effective timeout for probing this instance:
+// the service's own Timeout, else the environment's DefaultTimeout, else
+// defaultProbeTimeout.
+func (ds *ServiceInstance) probeTimeout() time.Duration {
+	if ds.Definition.Timeout > 0 {
+		return ds.Definition.Timeout
+	}
+	if ds.Environment.DefaultTimeout > 0 {
+		return ds.Environment.DefaultTimeout
+	}
+	return defaultProbeTimeout
 }
 
 type ServiceStatus struct {
@@ -171,6 +268,7 @@ func NewDeployment(configuration Configuration, urlAssembler UrlAssembler) *Depl
 				Environment:  env,
 				Definition:   ws,
 				UrlAssembler: urlAssembler,
+				Transport:    transportForEnvironment(env),
 			}
 			cell.DeployedService = &deployedService
 			row.Cells = append(row.Cells, cell)
@@ -178,63 +276,205 @@ func NewDeployment(configuration Configuration, urlAssembler UrlAssembler) *Depl
 		}
 		rows = append(rows, row)
 	}
-	return &Deployment{config: configuration, Rows: rows}
+	alertEngine, err := alerts.BuildEngine(configuration.Alerts)
+	if err != nil {
+		log.Fatalln("Could not build alerts engine:", err)
+	}
+	historyStore, err := history.BuildStore(configuration.History)
+	if err != nil {
+		log.Fatalln("Could not build history store:", err)
+	}
+	return &Deployment{config: configuration, Rows: rows, Alerts: alertEngine, History: historyStore, mu: new(sync.RWMutex)}
 }
 
+// makeOverview snapshots the current grid into a fresh DeploymentOverview,
+// holding mu for as little time as possible so callers (HTML rendering,
+// JSON encoding) can read it afterwards without racing a concurrent
+// fetchVersions.
 func (d Deployment) makeOverview() *DeploymentOverview {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	rows := make([]Row, len(d.Rows))
+	for i, row := range d.Rows {
+		cells := make([]*DeploymentCell, len(row.Cells))
+		for j, cell := range row.Cells {
+			cellCopy := *cell
+			cells[j] = &cellCopy
+		}
+		rows[i] = Row{Even: row.Even, Name: row.Name, Cells: cells}
+	}
 	return &DeploymentOverview{
 		Environments: d.config.Environments,
 		WebServices:  d.config.WebServices,
-		Rows:         d.Rows,
+		Rows:         rows,
 	}
 }
 
-func (d Deployment) fetchVersions() {
+// fetchVersions refreshes every cell in the grid, bounded to at most
+// d.MaxConcurrency concurrent probes (unbounded if zero). If updates is
+// non-nil, a CellUpdate is sent on it as soon as each cell's content is
+// refreshed, rather than only after every cell has finished - this backs
+// the SSE stream served by NewMux. ctx is attached to every probe so it can
+// be cancelled (e.g. a client disconnecting or a deadline from
+// WaitForVersion) without waiting for stragglers.
+func (d Deployment) fetchVersions(ctx context.Context, updates chan<- CellUpdate) {
+	var cells []*DeploymentCell
+	for _, row := range d.Rows {
+		cells = append(cells, row.Cells...)
+	}
+	d.fetchCells(ctx, cells, updates)
+}
+
+// fetchCells refreshes exactly cells, bounded to at most d.MaxConcurrency
+// concurrent probes (unbounded if zero). Unlike fetchVersions, which always
+// probes the whole grid, this lets a caller that only cares about a subset
+// - e.g. WaitForVersion watching one service - avoid reprobing, and
+// mutating the shared Content of, every other cell. If updates is non-nil,
+// a CellUpdate is sent on it as soon as each cell's content is refreshed,
+// rather than only after every cell has finished - this backs the SSE
+// stream served by NewMux. ctx is attached to every probe so it can be
+// cancelled (e.g. a client disconnecting or a deadline from
+// WaitForVersion) without waiting for stragglers.
+func (d Deployment) fetchCells(ctx context.Context, cells []*DeploymentCell, updates chan<- CellUpdate) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var semaphore chan struct{}
+	if d.MaxConcurrency > 0 {
+		semaphore = make(chan struct{}, d.MaxConcurrency)
+	}
+	deps := probeDependencies{Metrics: d.Metrics, History: d.History, RecentChangesLimit: d.RecentChangesLimit}
 
 	waitGroup := new(sync.WaitGroup)
-	for _, ws := range d.Rows {
-		for _, cell := range ws.Cells {
-			waitGroup.Add(1)
-			// Asyncronous using Go Routines
-			go func(finalCell *DeploymentCell, wg *sync.WaitGroup) {
-				finalCell.updateCellContent(time.Second)
-				wg.Done()
-			}(cell, waitGroup)
-		}
+	for _, cell := range cells {
+		waitGroup.Add(1)
+		// Asyncronous using Go Routines
+		go func(finalCell *DeploymentCell, wg *sync.WaitGroup) {
+			defer wg.Done()
+			if semaphore != nil {
+				select {
+				case semaphore <- struct{}{}:
+					defer func() { <-semaphore }()
+				case <-ctx.Done():
+					return
+				}
+			}
+			finalCell.updateCellContent(ctx, deps, updates)
+		}(cell, waitGroup)
 	}
 	waitGroup.Wait()
 	log.Print("Loaded all service informations")
 }
 
-func (d Deployment) createOverviewGrid() *DeploymentOverview {
-	d.fetchVersions()
+func (d Deployment) createOverviewGrid(ctx context.Context) *DeploymentOverview {
+	d.fetchVersions(ctx, nil)
+	d.evaluateAlerts(ctx)
 	return d.makeOverview()
 }
 
-func (d Deployment) WriteTable(wr io.Writer) error {
-	return versionOverviewTemplate.Execute(wr, d.createOverviewGrid())
+// evaluateAlerts runs the alerts engine against the current Rows and
+// applies any firing alerts back onto their cells.
+func (d Deployment) evaluateAlerts(ctx context.Context) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	firing := d.Alerts.Process(ctx, snapshotFromRows(d.Rows))
+	applyFiringAlerts(d.Rows, firing)
+}
+
+// snapshotFromRows converts the live grid into the plain alerts.Snapshot
+// type rules are evaluated against.
+func snapshotFromRows(rows []Row) alerts.Snapshot {
+	var cells []alerts.CellSnapshot
+	for _, row := range rows {
+		for _, cell := range row.Cells {
+			instance := cell.DeployedService
+			var buildTime time.Time
+			if !cell.Content.IsError {
+				if parsed, err := time.Parse(time.RFC3339, cell.Content.Title); err == nil {
+					buildTime = parsed
+				}
+			}
+			cells = append(cells, alerts.CellSnapshot{
+				Environment: instance.Environment.Name,
+				Service:     instance.Definition.Name,
+				IsError:     cell.Content.IsError,
+				Version:     cell.Content.Text,
+				BuildTime:   buildTime,
+				ProbedAt:    cell.Content.LastProbedAt,
+			})
+		}
+	}
+	return alerts.Snapshot{Cells: cells}
+}
+
+// applyFiringAlerts stamps each cell's Content.Alerts with the messages of
+// any currently-firing alert for its environment/service.
+func applyFiringAlerts(rows []Row, firing []alerts.Alert) {
+	messagesByCell := make(map[string][]string, len(firing))
+	for _, alert := range firing {
+		if alert.Resolved {
+			continue
+		}
+		key := cellAlertKey(alert.Environment, alert.Service)
+		messagesByCell[key] = append(messagesByCell[key], alert.Message)
+	}
+	for _, row := range rows {
+		for _, cell := range row.Cells {
+			instance := cell.DeployedService
+			key := cellAlertKey(instance.Environment.Name, instance.Definition.Name)
+			cell.Content.Alerts = messagesByCell[key]
+		}
+	}
+}
+
+func cellAlertKey(environment, service string) string {
+	return environment + "/" + service
+}
+
+// WriteTable renders the HTML overview to wr. ctx is cancelled-checked
+// throughout the probing so an HTTP handler can abort the render when its
+// client disconnects.
+func (d Deployment) WriteTable(ctx context.Context, wr io.Writer) error {
+	return versionOverviewTemplate.Execute(wr, d.createOverviewGrid(ctx))
 }
 
 func (instance ServiceInstance) createKey() string {
 	return instance.Environment.Name + "_" + instance.Definition.Name
 }
 
-func (ds *ServiceInstance) getStatus(timeout time.Duration) (*ServiceStatus, error) {
+func (ds *ServiceInstance) getStatus(ctx context.Context) (*ServiceStatus, error) {
 	infoEndpoint := ds.UrlAssembler.InfoEndpoint(ds.Environment, ds.Definition)
-	spaceClient := http.Client{
-		Timeout: timeout,
+
+	ctx, cancel := context.WithTimeout(ctx, ds.probeTimeout())
+	defer cancel()
+
+	transport := ds.Transport
+	if transport == nil {
+		transport = defaultTransport{}
+	}
+	spaceClient, header, transportErr := transport.Client(ds.Environment, ds.Definition)
+	if transportErr != nil {
+		log.Print("Error building transport for "+infoEndpoint+": ", transportErr)
+		return nil, transportErr
 	}
 
-	req, err := http.NewRequest(http.MethodGet, infoEndpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, infoEndpoint, nil)
 	if err != nil {
 		log.Fatal(err)
 		return nil, WebServiceDefinitionError
 	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
 
 	res, getErr := spaceClient.Do(req)
 	if getErr != nil {
 		log.Print("Error reading API "+infoEndpoint+": ", getErr)
-		if strings.Contains(getErr.Error(), "request cancelled") {
+		if errors.Is(getErr, context.DeadlineExceeded) {
 			return nil, TimeOutError
 		}
 		return nil, getErr
@@ -264,23 +504,123 @@ func (ds *ServiceInstance) getStatus(timeout time.Duration) (*ServiceStatus, err
 	return ds.Status, nil
 }
 
-func (dc *DeploymentCell) updateCellContent(timeout time.Duration) {
+// probeDependencies bundles the optional, cross-cutting collaborators a
+// single cell's probe reports to: metrics and version history. Grouped into
+// one struct so fetchCells doesn't have to thread
+// an ever-growing parameter list down to updateCellContent.
+type probeDependencies struct {
+	Metrics            *metrics.Collectors
+	History            history.Store
+	RecentChangesLimit int
+}
+
+func (dc *DeploymentCell) updateCellContent(ctx context.Context, deps probeDependencies, updates chan<- CellUpdate) {
 	instance := dc.DeployedService
-	status, err := instance.getStatus(timeout)
+	started := time.Now()
+	status, err := instance.getStatus(ctx)
+	duration := time.Since(started)
+	environment, service := instance.Environment.Name, instance.Definition.Name
 	if err == nil {
 		key := instance.createKey()
 		instance.Status = status
 		log.Print(key + " --> " + status.BuildInfo.Version)
 		dc.Content = DeploymentCellContent{
-			Text:    status.BuildInfo.Version,
-			Title:   status.BuildInfo.BuildTime,
-			IsError: false,
+			Text:         status.BuildInfo.Version,
+			Title:        status.BuildInfo.BuildTime,
+			IsError:      false,
+			LastProbedAt: started,
+		}
+		if deps.Metrics != nil {
+			deps.Metrics.ObserveSuccess(environment, service, status.BuildInfo.Version, duration)
+		}
+		if deps.History != nil {
+			dc.recordHistory(ctx, deps, environment, service, status, started)
 		}
 	} else {
 		dc.Content = DeploymentCellContent{
-			Text:    "??",
-			Title:   err.Error(),
-			IsError: true,
+			Text:         "??",
+			Title:        err.Error(),
+			IsError:      true,
+			ErrorKind:    errorKind(err),
+			LastProbedAt: started,
+		}
+		if deps.Metrics != nil {
+			deps.Metrics.ObserveFailure(environment, service, errorKind(err), duration)
 		}
 	}
+	if updates != nil {
+		updates <- CellUpdate{Environment: environment, Service: service, Content: dc.Content}
+	}
+}
+
+// recordHistory persists this probe and refreshes the cell's RecentChanges
+// panel from what the store now holds.
+func (dc *DeploymentCell) recordHistory(ctx context.Context, deps probeDependencies, environment, service string, status *ServiceStatus, probedAt time.Time) {
+	event := history.Event{
+		Environment: environment,
+		Service:     service,
+		Version:     status.BuildInfo.Version,
+		BuildTime:   status.BuildInfo.BuildTime,
+		ProbedAt:    probedAt,
+	}
+	if err := deps.History.Record(ctx, event); err != nil {
+		log.Print("Error recording history for "+environment+"/"+service+": ", err)
+		return
+	}
+
+	limit := deps.RecentChangesLimit
+	if limit <= 0 {
+		limit = historyChangesLimitDefault
+	}
+	// Transitions dedupes consecutive repeats, so ask the store for a few
+	// more than limit raw events to make it likely enough survive
+	// deduplication to fill the panel, without falling back to an
+	// unbounded query that gets slower as history grows. Ask for one event
+	// more than that window so a full result can be told apart from one
+	// that happens to exactly fill it: if we get the extra event back, the
+	// window was truncated and the oldest transition inside it might just
+	// be where the window starts, not when that version was actually
+	// adopted, so it's dropped rather than shown with a misleading
+	// timestamp.
+	window := limit * historyQueryLimitFactor
+	events, err := deps.History.Query(ctx, history.Filter{Environment: environment, Service: service, Limit: window + 1})
+	if err != nil {
+		log.Print("Error querying history for "+environment+"/"+service+": ", err)
+		return
+	}
+	truncated := len(events) > window
+	if truncated {
+		events = events[1:]
+	}
+	transitions := history.Transitions(events, 0)
+	if truncated && len(transitions) > 0 {
+		transitions = transitions[1:]
+	}
+	if len(transitions) > limit {
+		transitions = transitions[len(transitions)-limit:]
+	}
+	var changes []string
+	for _, transition := range transitions {
+		changes = append(changes, transition.ProbedAt.Format(time.RFC3339)+": "+transition.Version)
+	}
+	dc.Content.RecentChanges = changes
+}
+
+// errorKind classifies an error returned by getStatus into the coarse
+// buckets exposed on the probe_outcomes_total metric.
+func errorKind(err error) string {
+	var httpErr *HttpStatusError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.Is(err, WebServiceDefinitionError):
+		return "WebServiceDefinitionError"
+	case errors.Is(err, TimeOutError):
+		return "TimeOutError"
+	case errors.As(err, &httpErr):
+		return "HttpStatusError"
+	case errors.As(err, &syntaxErr):
+		return "json"
+	default:
+		return "network"
+	}
 }
@@ -0,0 +1,47 @@
+package history
+
+import "fmt"
+
+// Config is the JSON-declarable shape of a history backend, meant to be
+// embedded in the root package's Configuration. Type selects which of
+// JSONL/SQLite/S3 applies; a zero Config means no history is recorded.
+type Config struct {
+	Type   string
+	JSONL  *JSONLConfig
+	SQLite *SQLiteConfig
+	S3     *S3Config
+}
+
+type JSONLConfig struct {
+	Path string
+}
+
+type SQLiteConfig struct {
+	DataSourceName string
+}
+
+// BuildStore turns a Config into a ready-to-use Store. An empty Type
+// returns a nil Store and no error, meaning history recording is disabled.
+func BuildStore(config Config) (Store, error) {
+	switch config.Type {
+	case "":
+		return nil, nil
+	case "jsonl":
+		if config.JSONL == nil {
+			return nil, fmt.Errorf("jsonl history store requires a JSONL block")
+		}
+		return NewJSONLStore(config.JSONL.Path)
+	case "sqlite":
+		if config.SQLite == nil {
+			return nil, fmt.Errorf("sqlite history store requires a SQLite block")
+		}
+		return NewSQLiteStore(config.SQLite.DataSourceName)
+	case "s3":
+		if config.S3 == nil {
+			return nil, fmt.Errorf("s3 history store requires an S3 block")
+		}
+		return NewS3Store(*config.S3)
+	default:
+		return nil, fmt.Errorf("unknown history store type %q", config.Type)
+	}
+}
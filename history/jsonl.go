@@ -0,0 +1,81 @@
+package history
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// JSONLStore appends one JSON object per line to a file, and answers
+// queries by scanning it. It's the simplest Store, good for a single
+// instance without a database.
+type JSONLStore struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// NewJSONLStore opens (creating if needed) the JSONL file at path.
+func NewJSONLStore(path string) (*JSONLStore, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return &JSONLStore{Path: path}, nil
+}
+
+func (s *JSONLStore) Record(ctx context.Context, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+func (s *JSONLStore) Query(ctx context.Context, filter Filter) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.Open(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// With a Limit, only the most recent matches are kept as the file is
+	// scanned, so a growing history doesn't also grow the memory this
+	// allocates on every query - the scan itself is still O(file size),
+	// inherent to a flat, unindexed file.
+	var matched []Event
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, err
+		}
+		if !filter.Matches(event) {
+			continue
+		}
+		matched = append(matched, event)
+		if filter.Limit > 0 && len(matched) > filter.Limit {
+			matched = matched[1:]
+		}
+	}
+	return matched, scanner.Err()
+}
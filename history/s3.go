@@ -0,0 +1,147 @@
+package history
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config configures an S3Store against any S3-compatible object store,
+// including MinIO.
+type S3Config struct {
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	Bucket          string
+	Prefix          string
+	UseSSL          bool
+}
+
+// S3Store records one object per event under the key
+// Prefix + environment/service/probedAt.json, for teams that already run an
+// S3-compatible object store and would rather not stand up a database.
+// Prefix is prepended as is, with no separator added.
+type S3Store struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// NewS3Store connects to the object store described by config.
+func NewS3Store(config S3Config) (*S3Store, error) {
+	client, err := minio.New(config.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.AccessKeyID, config.SecretAccessKey, ""),
+		Secure: config.UseSSL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &S3Store{client: client, bucket: config.Bucket, prefix: config.Prefix}, nil
+}
+
+// objectKeyTimeLayout is fixed-width, so object keys sort lexically in
+// chronological order - Query relies on this to find the most recent
+// matches without reading every object.
+const objectKeyTimeLayout = "20060102T150405.000000000Z"
+
+func (s *S3Store) objectKey(event Event) string {
+	return fmt.Sprintf("%s%s/%s/%s.json",
+		s.prefix, event.Environment, event.Service, event.ProbedAt.UTC().Format(objectKeyTimeLayout))
+}
+
+// parseObjectKey recovers the environment, service and probedAt encoded in
+// an object key without fetching its body, so Query can filter and bound a
+// listing before paying for any GetObject calls.
+func (s *S3Store) parseObjectKey(key string) (environment, service string, probedAt time.Time, ok bool) {
+	trimmed := strings.TrimPrefix(key, s.prefix)
+	trimmed = strings.TrimSuffix(trimmed, ".json")
+	parts := strings.Split(trimmed, "/")
+	if len(parts) != 3 {
+		return "", "", time.Time{}, false
+	}
+	probedAt, err := time.Parse(objectKeyTimeLayout, parts[2])
+	if err != nil {
+		return "", "", time.Time{}, false
+	}
+	return parts[0], parts[1], probedAt, true
+}
+
+func (s *S3Store) Record(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, s.bucket, s.objectKey(event), bytes.NewReader(payload), int64(len(payload)),
+		minio.PutObjectOptions{ContentType: "application/json"})
+	return err
+}
+
+// Query lists matching keys (one cheap ListObjects call) and only pays for
+// a GetObject per kept match, rather than fetching every object in the
+// history to find the handful a caller actually wants. Object keys are
+// lexically, hence chronologically, ordered, so listing already yields
+// matches oldest-first and a Limit can simply keep the tail.
+func (s *S3Store) Query(ctx context.Context, filter Filter) ([]Event, error) {
+	prefix := s.prefix
+	if filter.Environment != "" {
+		prefix += filter.Environment + "/"
+		if filter.Service != "" {
+			prefix += filter.Service + "/"
+		}
+	}
+
+	var keys []string
+	for object := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+		if !strings.HasSuffix(object.Key, ".json") {
+			continue
+		}
+		environment, service, probedAt, ok := s.parseObjectKey(object.Key)
+		if !ok {
+			continue
+		}
+		if !filter.Matches(Event{Environment: environment, Service: service, ProbedAt: probedAt}) {
+			continue
+		}
+		keys = append(keys, object.Key)
+		if filter.Limit > 0 && len(keys) > filter.Limit {
+			keys = keys[1:]
+		}
+	}
+
+	events := make([]Event, 0, len(keys))
+	for _, key := range keys {
+		event, err := s.getEvent(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (s *S3Store) getEvent(ctx context.Context, key string) (Event, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return Event{}, err
+	}
+	defer object.Close()
+
+	body, err := io.ReadAll(object)
+	if err != nil {
+		return Event{}, err
+	}
+
+	var event Event
+	err = json.Unmarshal(body, &event)
+	return event, err
+}
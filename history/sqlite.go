@@ -0,0 +1,112 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteStore records history in a SQLite database via database/sql. It
+// depends on github.com/mattn/go-sqlite3, which uses cgo, so building with
+// this store requires CGO_ENABLED=1 and a C toolchain.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (creating if needed) a SQLite database at
+// dataSourceName and ensures its schema exists.
+func NewSQLiteStore(dataSourceName string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite3", dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	store := &SQLiteStore{db: db}
+	if err := store.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *SQLiteStore) migrate() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS history_events (
+			environment TEXT NOT NULL,
+			service     TEXT NOT NULL,
+			version     TEXT NOT NULL,
+			build_time  TEXT NOT NULL,
+			probed_at   DATETIME NOT NULL
+		)`)
+	return err
+}
+
+func (s *SQLiteStore) Record(ctx context.Context, event Event) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO history_events (environment, service, version, build_time, probed_at) VALUES (?, ?, ?, ?, ?)`,
+		event.Environment, event.Service, event.Version, event.BuildTime, event.ProbedAt)
+	return err
+}
+
+func (s *SQLiteStore) Query(ctx context.Context, filter Filter) ([]Event, error) {
+	query := `SELECT environment, service, version, build_time, probed_at FROM history_events WHERE 1=1`
+	var args []interface{}
+	if filter.Environment != "" {
+		query += " AND environment = ?"
+		args = append(args, filter.Environment)
+	}
+	if filter.Service != "" {
+		query += " AND service = ?"
+		args = append(args, filter.Service)
+	}
+	if !filter.Since.IsZero() {
+		query += " AND probed_at > ?"
+		args = append(args, filter.Since)
+	}
+
+	// Without a Limit, keep the historic ascending order. With one, let the
+	// database pick the most recent rows with an index-friendly ORDER BY ...
+	// DESC LIMIT, then reverse back to ascending - cheaper than scanning the
+	// whole table to find the tail.
+	if filter.Limit > 0 {
+		query += " ORDER BY probed_at DESC LIMIT ?"
+		args = append(args, filter.Limit)
+	} else {
+		query += " ORDER BY probed_at ASC"
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying history: %w", err)
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var event Event
+		if err := rows.Scan(&event.Environment, &event.Service, &event.Version, &event.BuildTime, &event.ProbedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if filter.Limit > 0 {
+		reverseEvents(events)
+	}
+	return events, nil
+}
+
+// reverseEvents reverses events in place.
+func reverseEvents(events []Event) {
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+}
+
+// Close releases the underlying database connection.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,74 @@
+// Package history records version timelines for the deployment grid so
+// overview pages can answer "when did prod get 1.4.2?" without reprobing
+// the services themselves. Like the alerts package, it operates on its own
+// plain Event type rather than the root package's, so implementations and
+// their configuration can be tested without it.
+package history
+
+import (
+	"context"
+	"time"
+)
+
+// Event is one successful probe of a cell, recorded after the fact.
+type Event struct {
+	Environment string    `json:"environment"`
+	Service     string    `json:"service"`
+	Version     string    `json:"version"`
+	BuildTime   string    `json:"buildTime"`
+	ProbedAt    time.Time `json:"probedAt"`
+}
+
+// Filter narrows a Query. Environment and Service select a single cell;
+// either left empty matches any. Since, if non-zero, excludes events probed
+// at or before it. Limit, if non-zero, bounds the result to the most recent
+// Limit matching events (oldest first, like the rest of a Query result), so
+// callers that only need a recent window - e.g. the "recent changes" panel
+// - don't force a backend to walk its entire history on every refresh.
+type Filter struct {
+	Environment string
+	Service     string
+	Since       time.Time
+	Limit       int
+}
+
+// Matches reports whether event satisfies f.
+func (f Filter) Matches(event Event) bool {
+	if f.Environment != "" && f.Environment != event.Environment {
+		return false
+	}
+	if f.Service != "" && f.Service != event.Service {
+		return false
+	}
+	if !f.Since.IsZero() && !event.ProbedAt.After(f.Since) {
+		return false
+	}
+	return true
+}
+
+// Store is a pluggable backend for recording and querying version history.
+type Store interface {
+	Record(ctx context.Context, event Event) error
+	Query(ctx context.Context, filter Filter) ([]Event, error)
+}
+
+// Transitions returns the last limit events in events whose Version differs
+// from the one immediately before it, preserving order and dropping
+// repeats - e.g. for a "changes" panel that only cares about transitions.
+func Transitions(events []Event, limit int) []Event {
+	var transitions []Event
+	previousVersion := ""
+	first := true
+	for _, event := range events {
+		if !first && event.Version == previousVersion {
+			continue
+		}
+		transitions = append(transitions, event)
+		previousVersion = event.Version
+		first = false
+	}
+	if limit > 0 && len(transitions) > limit {
+		transitions = transitions[len(transitions)-limit:]
+	}
+	return transitions
+}
@@ -0,0 +1,97 @@
+// Package metrics exposes Prometheus collectors for the deployment grid so
+// operators can scrape probe health and version drift the same way they
+// already scrape their services.
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "web_service_overview"
+
+// Collectors bundles the Prometheus collectors registered for a Deployment.
+// It is safe for concurrent use, since the underlying collectors are and
+// access to lastVersion is guarded by mu.
+type Collectors struct {
+	registry *prometheus.Registry
+
+	ProbeSuccess      *prometheus.GaugeVec
+	ProbeDuration     *prometheus.HistogramVec
+	ProbeOutcomes     *prometheus.CounterVec
+	DeployedBuildInfo *prometheus.GaugeVec
+
+	mu sync.Mutex
+	// lastVersion tracks the version last set on DeployedBuildInfo for each
+	// environment/service, keyed by "environment/service", so ObserveSuccess
+	// can clear the stale series when the version changes.
+	lastVersion map[string]string
+}
+
+// NewCollectors creates and registers a fresh set of collectors in their own
+// registry, so several Deployments can be instrumented without clashing.
+func NewCollectors() *Collectors {
+	c := &Collectors{
+		registry: prometheus.NewRegistry(),
+		ProbeSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "probe_success",
+			Help:      "1 if the last probe of a cell succeeded, 0 otherwise.",
+		}, []string{"environment", "service"}),
+		ProbeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "probe_duration_seconds",
+			Help:      "Duration of probing a service instance's info endpoint.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"environment", "service"}),
+		ProbeOutcomes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "probe_outcomes_total",
+			Help:      "Count of probe outcomes, partitioned by error kind (empty for success).",
+		}, []string{"environment", "service", "error_kind"}),
+		DeployedBuildInfo: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "deployed_build_info",
+			Help:      "Always 1, labeled with the version last observed for a cell.",
+		}, []string{"environment", "service", "version"}),
+		lastVersion: make(map[string]string),
+	}
+	c.registry.MustRegister(c.ProbeSuccess, c.ProbeDuration, c.ProbeOutcomes, c.DeployedBuildInfo)
+	return c
+}
+
+// Handler returns the HTTP handler that serves these collectors in the
+// Prometheus text format, mountable next to WriteTable.
+func (c *Collectors) Handler() http.Handler {
+	return promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveSuccess records a successful probe of environment/service that
+// reported version, taking duration to complete.
+func (c *Collectors) ObserveSuccess(environment, service, version string, duration time.Duration) {
+	c.ProbeSuccess.WithLabelValues(environment, service).Set(1)
+	c.ProbeDuration.WithLabelValues(environment, service).Observe(duration.Seconds())
+	c.ProbeOutcomes.WithLabelValues(environment, service, "").Inc()
+
+	key := environment + "/" + service
+	c.mu.Lock()
+	if previous, ok := c.lastVersion[key]; ok && previous != version {
+		c.DeployedBuildInfo.DeleteLabelValues(environment, service, previous)
+	}
+	c.lastVersion[key] = version
+	c.mu.Unlock()
+	c.DeployedBuildInfo.WithLabelValues(environment, service, version).Set(1)
+}
+
+// ObserveFailure records a failed probe of environment/service, classified
+// by errorKind (e.g. "WebServiceDefinitionError", "TimeOutError",
+// "HttpStatusError", "json", "network").
+func (c *Collectors) ObserveFailure(environment, service, errorKind string, duration time.Duration) {
+	c.ProbeSuccess.WithLabelValues(environment, service).Set(0)
+	c.ProbeDuration.WithLabelValues(environment, service).Observe(duration.Seconds())
+	c.ProbeOutcomes.WithLabelValues(environment, service, errorKind).Inc()
+}
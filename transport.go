@@ -0,0 +1,200 @@
+package web_service_overview
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ProbeTransport builds the HTTP client and extra headers used to probe a
+// service instance's info endpoint, letting environments mix public and
+// internal, mutually-authenticated services on a single overview page.
+type ProbeTransport interface {
+	Client(environment Environment, definition WebServiceDefinition) (*http.Client, http.Header, error)
+}
+
+// defaultTransport is used when an Environment declares neither Auth nor
+// TLS: a bare, unauthenticated client, matching the original behaviour.
+type defaultTransport struct{}
+
+func (defaultTransport) Client(Environment, WebServiceDefinition) (*http.Client, http.Header, error) {
+	return &http.Client{}, http.Header{}, nil
+}
+
+// BasicAuthTransport sends credentials via HTTP Basic authentication.
+type BasicAuthTransport struct {
+	Username string
+	Password string
+}
+
+func (b BasicAuthTransport) Client(Environment, WebServiceDefinition) (*http.Client, http.Header, error) {
+	header := http.Header{}
+	credentials := base64.StdEncoding.EncodeToString([]byte(b.Username + ":" + b.Password))
+	header.Set("Authorization", "Basic "+credentials)
+	return &http.Client{}, header, nil
+}
+
+// BearerTokenTransport sends a bearer (or JWT) token as an Authorization
+// header. Exactly one of Token, TokenEnv or TokenFile should be set; the
+// token is resolved on every probe so a rotated file or env var is picked
+// up without restarting the process.
+type BearerTokenTransport struct {
+	Token     string
+	TokenEnv  string
+	TokenFile string
+}
+
+func (bt BearerTokenTransport) resolveToken() (string, error) {
+	if bt.Token != "" {
+		return bt.Token, nil
+	}
+	if bt.TokenEnv != "" {
+		token := os.Getenv(bt.TokenEnv)
+		if token == "" {
+			return "", fmt.Errorf("environment variable %s is not set", bt.TokenEnv)
+		}
+		return token, nil
+	}
+	if bt.TokenFile != "" {
+		contents, err := ioutil.ReadFile(bt.TokenFile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(contents)), nil
+	}
+	return "", errors.New("BearerTokenTransport has no token source configured")
+}
+
+func (bt BearerTokenTransport) Client(Environment, WebServiceDefinition) (*http.Client, http.Header, error) {
+	token, err := bt.resolveToken()
+	if err != nil {
+		return nil, nil, err
+	}
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+	return &http.Client{}, header, nil
+}
+
+// TLSTransport configures the client's TLS settings: CAFile to trust a
+// self-signed internal PKI, CertFile/KeyFile together for mTLS, or both for
+// a mutually-authenticated connection against a private CA.
+type TLSTransport struct {
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+func (t TLSTransport) Client(Environment, WebServiceDefinition) (*http.Client, http.Header, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: t.InsecureSkipVerify}
+
+	if t.CAFile != "" {
+		caCert, err := ioutil.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, nil, fmt.Errorf("could not parse CA certificate %s", t.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if t.CertFile != "" && t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}, http.Header{}, nil
+}
+
+// CombinedTransport merges the headers produced by Auth with the *http.Client
+// built by TLS, so e.g. a bearer token can be sent over a mutually
+// authenticated connection.
+type CombinedTransport struct {
+	Auth ProbeTransport
+	TLS  ProbeTransport
+}
+
+func (c CombinedTransport) Client(environment Environment, definition WebServiceDefinition) (*http.Client, http.Header, error) {
+	client, _, err := c.TLS.Client(environment, definition)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, header, err := c.Auth.Client(environment, definition)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, header, nil
+}
+
+// AuthConfig declares how to authenticate probes against an Environment.
+// Exactly one of BasicAuth or Bearer should be set.
+type AuthConfig struct {
+	BasicAuth *BasicAuthConfig
+	Bearer    *BearerAuthConfig
+}
+
+type BasicAuthConfig struct {
+	Username string
+	Password string
+}
+
+type BearerAuthConfig struct {
+	Token     string
+	TokenEnv  string
+	TokenFile string
+}
+
+// TLSConfig declares the TLS settings for probing an Environment.
+type TLSConfig struct {
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+// transportForEnvironment builds the ProbeTransport described by an
+// Environment's Auth and TLS config, falling back to defaultTransport when
+// neither is set.
+func transportForEnvironment(environment Environment) ProbeTransport {
+	var authTransport ProbeTransport = defaultTransport{}
+	if environment.Auth != nil {
+		switch {
+		case environment.Auth.BasicAuth != nil:
+			authTransport = BasicAuthTransport{
+				Username: environment.Auth.BasicAuth.Username,
+				Password: environment.Auth.BasicAuth.Password,
+			}
+		case environment.Auth.Bearer != nil:
+			authTransport = BearerTokenTransport{
+				Token:     environment.Auth.Bearer.Token,
+				TokenEnv:  environment.Auth.Bearer.TokenEnv,
+				TokenFile: environment.Auth.Bearer.TokenFile,
+			}
+		}
+	}
+
+	if environment.TLS != nil {
+		return CombinedTransport{
+			Auth: authTransport,
+			TLS: TLSTransport{
+				CAFile:             environment.TLS.CAFile,
+				CertFile:           environment.TLS.CertFile,
+				KeyFile:            environment.TLS.KeyFile,
+				InsecureSkipVerify: environment.TLS.InsecureSkipVerify,
+			},
+		}
+	}
+
+	return authTransport
+}
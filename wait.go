@@ -0,0 +1,174 @@
+package web_service_overview
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AnyNewer is a sentinel WaitForVersion target meaning "wait for any
+// version different from the one observed when WaitForVersion was called",
+// rather than a specific version string.
+const AnyNewer = "\x00any-newer\x00"
+
+// WaitOptions configures WaitForVersion.
+type WaitOptions struct {
+	// Environments restricts which environments to wait on; empty means all
+	// environments the service is deployed to.
+	Environments []string
+	// PollInterval is how often to re-probe while waiting. Defaults to 5s.
+	PollInterval time.Duration
+	// Timeout bounds the overall wait. Zero means no timeout beyond ctx.
+	Timeout time.Duration
+	// MinStableProbes is how many consecutive probes must report the target
+	// version before it counts as reached, guarding against a
+	// load-balanced fleet still mid-rollout. Defaults to 1.
+	MinStableProbes int
+}
+
+func (o WaitOptions) withDefaults() WaitOptions {
+	if o.PollInterval <= 0 {
+		o.PollInterval = 5 * time.Second
+	}
+	if o.MinStableProbes <= 0 {
+		o.MinStableProbes = 1
+	}
+	return o
+}
+
+// LaggingCell describes a cell that had not yet reached the target version
+// when WaitForVersion gave up.
+type LaggingCell struct {
+	Environment string
+	Service     string
+	Version     string
+}
+
+// ConvergenceError is returned by WaitForVersion when ctx is cancelled or
+// the configured Timeout elapses before every targeted cell converges.
+type ConvergenceError struct {
+	Target  string
+	Lagging []LaggingCell
+	Err     error
+}
+
+func (e *ConvergenceError) Error() string {
+	return fmt.Sprintf("gave up waiting for %q on %d cell(s): %v", e.Target, len(e.Lagging), e.Err)
+}
+
+func (e *ConvergenceError) Unwrap() error {
+	return e.Err
+}
+
+// WaitForVersion blocks until every targeted cell of service reports target
+// for MinStableProbes consecutive rounds, or until ctx is cancelled or
+// opts.Timeout elapses. Pass AnyNewer as target to wait for any change from
+// the version observed when WaitForVersion was called.
+func (d Deployment) WaitForVersion(ctx context.Context, service string, target string, opts WaitOptions) error {
+	opts = opts.withDefaults()
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	cells, err := d.targetedCells(service, opts.Environments)
+	if err != nil {
+		return err
+	}
+
+	baseline := make(map[*DeploymentCell]string, len(cells))
+	if target == AnyNewer {
+		// Probe once up front so the baseline reflects what's actually
+		// deployed rather than a cell's zero-value Content.Text, which
+		// would make the very first probe below look like "changed".
+		d.fetchCells(ctx, cells, nil)
+		d.mu.RLock()
+		for _, cell := range cells {
+			baseline[cell] = cell.Content.Text
+		}
+		d.mu.RUnlock()
+	}
+
+	stableCounts := make(map[*DeploymentCell]int, len(cells))
+
+	ticker := time.NewTicker(opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.fetchCells(ctx, cells, nil)
+
+		lagging := d.evaluateConvergence(cells, target, baseline, stableCounts, opts.MinStableProbes)
+		if len(lagging) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return &ConvergenceError{Target: target, Lagging: lagging, Err: ctx.Err()}
+		case <-ticker.C:
+		}
+	}
+}
+
+// targetedCells resolves the cells WaitForVersion should watch: every cell
+// in the Row named service, optionally restricted to environments.
+func (d Deployment) targetedCells(service string, environments []string) ([]*DeploymentCell, error) {
+	for _, row := range d.Rows {
+		if row.Name != service {
+			continue
+		}
+		if len(environments) == 0 {
+			return row.Cells, nil
+		}
+		var filtered []*DeploymentCell
+		for _, cell := range row.Cells {
+			for _, environment := range environments {
+				if cell.DeployedService.Environment.Name == environment {
+					filtered = append(filtered, cell)
+					break
+				}
+			}
+		}
+		if len(filtered) == 0 {
+			return nil, fmt.Errorf("no cells of %q matched environments %v", service, environments)
+		}
+		return filtered, nil
+	}
+	return nil, fmt.Errorf("no WebServiceDefinition named %q in this deployment", service)
+}
+
+// evaluateConvergence updates stableCounts from the cells' current content
+// and returns the cells still lagging the target.
+func (d Deployment) evaluateConvergence(cells []*DeploymentCell, target string, baseline map[*DeploymentCell]string, stableCounts map[*DeploymentCell]int, minStableProbes int) []LaggingCell {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var lagging []LaggingCell
+	for _, cell := range cells {
+		reached := false
+		if !cell.Content.IsError {
+			if target == AnyNewer {
+				reached = cell.Content.Text != baseline[cell]
+			} else {
+				reached = cell.Content.Text == target
+			}
+		}
+
+		if reached {
+			stableCounts[cell]++
+		} else {
+			stableCounts[cell] = 0
+		}
+
+		if stableCounts[cell] < minStableProbes {
+			lagging = append(lagging, LaggingCell{
+				Environment: cell.DeployedService.Environment.Name,
+				Service:     cell.DeployedService.Definition.Name,
+				Version:     cell.Content.Text,
+			})
+		}
+	}
+	return lagging
+}